@@ -0,0 +1,62 @@
+// Package cache stores parsed dependency graphs keyed by the commit SHA
+// they were built from, so re-rendering a repository that hasn't moved
+// skips re-cloning and re-parsing it.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/suessflorian/go-dep-visual/graph"
+)
+
+// Entry is what gets stored and retrieved for a single commit SHA.
+type Entry struct {
+	// Graph is the dependency graph built by main's AST walk, file
+	// provenance included.
+	Graph graph.Graph `json:"graph"`
+}
+
+// Storage persists Entry values keyed by commit SHA. Implementations must
+// be safe to use with a Get immediately following a Put for the same key.
+type Storage interface {
+	Get(ctx context.Context, sha string) (*Entry, bool, error)
+	Put(ctx context.Context, sha string, entry *Entry) error
+}
+
+// Open selects a Storage implementation based on uri's scheme:
+//
+//	file://path  -> local disk
+//	s3://bucket  -> AWS S3
+//	gs://bucket  -> Google Cloud Storage
+func Open(uri string) (Storage, error) {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid cache URI %q: missing scheme (want file://, s3:// or gs://)", uri)
+	}
+
+	switch scheme {
+	case "file":
+		return newLocalStorage(rest)
+	case "s3":
+		return newS3Storage(rest)
+	case "gs":
+		return newGCSStorage(rest)
+	default:
+		return nil, fmt.Errorf("unsupported cache scheme %q", scheme)
+	}
+}
+
+func marshalEntry(entry *Entry) ([]byte, error) {
+	return json.Marshal(entry)
+}
+
+func unmarshalEntry(data []byte) (*Entry, error) {
+	entry := new(Entry)
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
@@ -0,0 +1,80 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"cloud.google.com/go/storage"
+)
+
+// gcsStorage stores one object per commit SHA under bucket/prefix.
+type gcsStorage struct {
+	client *storage.Client
+	bucket string
+	prefix string
+}
+
+func newGCSStorage(rest string) (*gcsStorage, error) {
+	if rest == "" {
+		return nil, fmt.Errorf("gs cache requires a bucket, e.g. gs://my-bucket/prefix")
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+
+	client, err := storage.NewClient(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("creating GCS client: %w", err)
+	}
+
+	return &gcsStorage{
+		client: client,
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (s *gcsStorage) object(sha string) *storage.ObjectHandle {
+	name := sha + ".json"
+	if s.prefix != "" {
+		name = s.prefix + "/" + name
+	}
+	return s.client.Bucket(s.bucket).Object(name)
+}
+
+func (s *gcsStorage) Get(ctx context.Context, sha string) (*Entry, bool, error) {
+	reader, err := s.object(sha).NewReader(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry, err := unmarshalEntry(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+func (s *gcsStorage) Put(ctx context.Context, sha string, entry *Entry) error {
+	data, err := marshalEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	writer := s.object(sha).NewWriter(ctx)
+	if _, err := writer.Write(data); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,52 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// localStorage keeps one JSON file per commit SHA under dir.
+type localStorage struct {
+	dir string
+}
+
+func newLocalStorage(dir string) (*localStorage, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("file cache requires a path, e.g. file://./.dep-cache")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("creating cache dir %s: %w", dir, err)
+	}
+	return &localStorage{dir: dir}, nil
+}
+
+func (s *localStorage) path(sha string) string {
+	return filepath.Join(s.dir, sha+".json")
+}
+
+func (s *localStorage) Get(ctx context.Context, sha string) (*Entry, bool, error) {
+	data, err := os.ReadFile(s.path(sha))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry, err := unmarshalEntry(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+func (s *localStorage) Put(ctx context.Context, sha string, entry *Entry) error {
+	data, err := marshalEntry(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path(sha), data, 0o644)
+}
@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// s3Storage stores one object per commit SHA under bucket/prefix.
+type s3Storage struct {
+	client *s3.Client
+	bucket string
+	prefix string
+}
+
+func newS3Storage(rest string) (*s3Storage, error) {
+	if rest == "" {
+		return nil, fmt.Errorf("s3 cache requires a bucket, e.g. s3://my-bucket/prefix")
+	}
+	bucket, prefix, _ := strings.Cut(rest, "/")
+
+	cfg, err := config.LoadDefaultConfig(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+
+	return &s3Storage{
+		client: s3.NewFromConfig(cfg),
+		bucket: bucket,
+		prefix: prefix,
+	}, nil
+}
+
+func (s *s3Storage) key(sha string) string {
+	if s.prefix == "" {
+		return sha + ".json"
+	}
+	return s.prefix + "/" + sha + ".json"
+}
+
+func (s *s3Storage) Get(ctx context.Context, sha string) (*Entry, bool, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(sha)),
+	})
+	var notFound *types.NoSuchKey
+	if errors.As(err, &notFound) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	defer out.Body.Close()
+
+	data, err := io.ReadAll(out.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	entry, err := unmarshalEntry(data)
+	if err != nil {
+		return nil, false, err
+	}
+	return entry, true, nil
+}
+
+func (s *s3Storage) Put(ctx context.Context, sha string, entry *Entry) error {
+	data, err := marshalEntry(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(sha)),
+		Body:   bytes.NewReader(data),
+	})
+	return err
+}
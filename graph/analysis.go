@@ -0,0 +1,90 @@
+package graph
+
+import (
+	"slices"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+)
+
+// Report is the machine-readable result of Analyze.
+type Report struct {
+	// Cycles lists each strongly connected component of size > 1 (or a
+	// self-importing package), one entry per component even when the
+	// component holds several cycles.
+	Cycles [][]string `json:"cycles"`
+	// FanOut is the number of distinct packages each package imports.
+	FanOut map[string]int `json:"fan_out"`
+	// FanIn is the number of distinct packages that import each package.
+	FanIn map[string]int `json:"fan_in"`
+	// UnusedRequires lists go.mod require paths that no walked import
+	// ever referenced.
+	UnusedRequires []string `json:"unused_requires,omitempty"`
+}
+
+// Analyze runs every analysis over g. modFile is optional: pass nil to skip
+// the unused-requires check (e.g. when no go.mod was found).
+func Analyze(g Graph, modFile *modfile.File) Report {
+	report := Report{
+		Cycles: SCCs(g),
+		FanOut: FanOut(g),
+		FanIn:  FanIn(g),
+	}
+	if modFile != nil {
+		report.UnusedRequires = UnusedRequires(g, modFile)
+	}
+	return report
+}
+
+// FanOut counts, for every package, how many distinct packages it imports.
+func FanOut(g Graph) map[string]int {
+	fanOut := make(map[string]int, len(g.Edges))
+	for pkg, deps := range g.Edges {
+		fanOut[pkg] = len(deps)
+	}
+	return fanOut
+}
+
+// FanIn counts, for every package, how many distinct packages import it.
+func FanIn(g Graph) map[string]int {
+	fanIn := make(map[string]int, len(g.Edges))
+	for pkg := range g.Edges {
+		fanIn[pkg] = 0
+	}
+	for _, deps := range g.Edges {
+		for dep := range deps {
+			fanIn[dep]++
+		}
+	}
+	return fanIn
+}
+
+// UnusedRequires returns every go.mod require path that is never the
+// prefix of a package actually imported somewhere in g.
+func UnusedRequires(g Graph, modFile *modfile.File) []string {
+	imported := make(map[string]bool)
+	for pkg, deps := range g.Edges {
+		imported[pkg] = true
+		for dep := range deps {
+			imported[dep] = true
+		}
+	}
+
+	var unused []string
+	for _, req := range modFile.Require {
+		path := req.Mod.Path
+		used := false
+		for pkg := range imported {
+			if pkg == path || strings.HasPrefix(pkg, path+"/") {
+				used = true
+				break
+			}
+		}
+		if !used {
+			unused = append(unused, path)
+		}
+	}
+
+	slices.Sort(unused)
+	return unused
+}
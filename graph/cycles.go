@@ -0,0 +1,135 @@
+package graph
+
+// SCCs returns the graph's strongly connected components that are actual
+// cycles: components with more than one package, plus any single package
+// that imports itself. Computed with Tarjan's algorithm, run iteratively
+// (an explicit stack rather than recursion) so it doesn't blow the stack on
+// large monorepo graphs.
+func SCCs(g Graph) [][]string {
+	t := &tarjan{
+		g:       g,
+		index:   make(map[string]int),
+		lowlink: make(map[string]int),
+		onStack: make(map[string]bool),
+	}
+
+	for _, pkg := range g.Packages() {
+		if _, visited := t.index[pkg]; !visited {
+			t.run(pkg)
+		}
+	}
+
+	return t.sccs
+}
+
+// CycleEdges returns the set of edges (keyed as "from -> to") that sit
+// inside one of SCCs' components.
+func CycleEdges(g Graph) map[string]bool {
+	cycles := make(map[string]bool)
+	for _, scc := range SCCs(g) {
+		members := make(map[string]bool, len(scc))
+		for _, pkg := range scc {
+			members[pkg] = true
+		}
+		for _, from := range scc {
+			for to := range g.Edges[from] {
+				if members[to] {
+					cycles[edgeKey(from, to)] = true
+				}
+			}
+		}
+	}
+	return cycles
+}
+
+func edgeKey(from, to string) string {
+	return from + " -> " + to
+}
+
+type tarjan struct {
+	g Graph
+
+	counter int
+	index   map[string]int
+	lowlink map[string]int
+	onStack map[string]bool
+	stack   []string
+
+	sccs [][]string
+}
+
+// frame is one level of the explicit DFS stack: the node being visited and
+// how far through its neighbour list we've got.
+type frame struct {
+	node     string
+	children []string
+	next     int
+}
+
+func (t *tarjan) run(start string) {
+	var call []*frame
+	push := func(node string) {
+		t.counter++
+		t.index[node] = t.counter
+		t.lowlink[node] = t.counter
+		t.stack = append(t.stack, node)
+		t.onStack[node] = true
+
+		neighbours := make([]string, 0, len(t.g.Edges[node]))
+		for to := range t.g.Edges[node] {
+			neighbours = append(neighbours, to)
+		}
+		call = append(call, &frame{node: node, children: neighbours})
+	}
+
+	push(start)
+
+	for len(call) > 0 {
+		top := call[len(call)-1]
+
+		if top.next < len(top.children) {
+			child := top.children[top.next]
+			top.next++
+
+			if _, visited := t.index[child]; !visited {
+				push(child)
+				continue
+			}
+			if t.onStack[child] && t.index[child] < t.lowlink[top.node] {
+				t.lowlink[top.node] = t.index[child]
+			}
+			continue
+		}
+
+		// All neighbours processed: pop this frame and propagate its
+		// lowlink up to the caller.
+		call = call[:len(call)-1]
+		if len(call) > 0 {
+			parent := call[len(call)-1]
+			if t.lowlink[top.node] < t.lowlink[parent.node] {
+				t.lowlink[parent.node] = t.lowlink[top.node]
+			}
+		}
+
+		if t.lowlink[top.node] == t.index[top.node] {
+			var scc []string
+			for {
+				n := t.stack[len(t.stack)-1]
+				t.stack = t.stack[:len(t.stack)-1]
+				t.onStack[n] = false
+				scc = append(scc, n)
+				if n == top.node {
+					break
+				}
+			}
+			if len(scc) > 1 || selfLoop(t.g, scc[0]) {
+				t.sccs = append(t.sccs, scc)
+			}
+		}
+	}
+}
+
+func selfLoop(g Graph, pkg string) bool {
+	_, ok := g.Edges[pkg][pkg]
+	return ok
+}
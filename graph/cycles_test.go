@@ -0,0 +1,129 @@
+package graph
+
+import (
+	"sort"
+	"testing"
+)
+
+func buildGraph(edges [][2]string) Graph {
+	g := New()
+	for _, e := range edges {
+		g.AddEdge(e[0], e[1], "file.go")
+	}
+	return g
+}
+
+func sortedSCCs(sccs [][]string) [][]string {
+	out := make([][]string, len(sccs))
+	for i, scc := range sccs {
+		members := append([]string(nil), scc...)
+		sort.Strings(members)
+		out[i] = members
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if len(out[i]) != len(out[j]) {
+			return len(out[i]) < len(out[j])
+		}
+		for k := range out[i] {
+			if out[i][k] != out[j][k] {
+				return out[i][k] < out[j][k]
+			}
+		}
+		return false
+	})
+	return out
+}
+
+func TestSCCs(t *testing.T) {
+	tests := map[string]struct {
+		edges [][2]string
+		want  [][]string
+	}{
+		"disjoint chain has no cycles": {
+			edges: [][2]string{{"a", "b"}, {"b", "c"}},
+			want:  nil,
+		},
+		"diamond has no cycles": {
+			edges: [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}},
+			want:  nil,
+		},
+		"self loop is a cycle": {
+			edges: [][2]string{{"a", "a"}},
+			want:  [][]string{{"a"}},
+		},
+		"two node cycle": {
+			edges: [][2]string{{"a", "b"}, {"b", "a"}},
+			want:  [][]string{{"a", "b"}},
+		},
+		"three node cycle": {
+			edges: [][2]string{{"a", "b"}, {"b", "c"}, {"c", "a"}},
+			want:  [][]string{{"a", "b", "c"}},
+		},
+		"cycle plus an unrelated chain": {
+			edges: [][2]string{{"a", "b"}, {"b", "a"}, {"c", "d"}},
+			want:  [][]string{{"a", "b"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := buildGraph(tc.edges)
+			got := sortedSCCs(SCCs(g))
+			want := sortedSCCs(tc.want)
+
+			if len(got) != len(want) {
+				t.Fatalf("SCCs() = %v, want %v", got, want)
+			}
+			for i := range got {
+				if len(got[i]) != len(want[i]) {
+					t.Fatalf("SCCs() = %v, want %v", got, want)
+				}
+				for j := range got[i] {
+					if got[i][j] != want[i][j] {
+						t.Fatalf("SCCs() = %v, want %v", got, want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestCycleEdges(t *testing.T) {
+	tests := map[string]struct {
+		edges [][2]string
+		want  map[string]bool
+	}{
+		"no cycle marks nothing": {
+			edges: [][2]string{{"a", "b"}, {"b", "c"}},
+			want:  map[string]bool{},
+		},
+		"self loop marks itself": {
+			edges: [][2]string{{"a", "a"}},
+			want:  map[string]bool{"a -> a": true},
+		},
+		"two node cycle marks both directions": {
+			edges: [][2]string{{"a", "b"}, {"b", "a"}},
+			want:  map[string]bool{"a -> b": true, "b -> a": true},
+		},
+		"edge leaving the cycle is not marked": {
+			edges: [][2]string{{"a", "b"}, {"b", "a"}, {"a", "c"}},
+			want:  map[string]bool{"a -> b": true, "b -> a": true},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			g := buildGraph(tc.edges)
+			got := CycleEdges(g)
+
+			if len(got) != len(tc.want) {
+				t.Fatalf("CycleEdges() = %v, want %v", got, tc.want)
+			}
+			for k := range tc.want {
+				if !got[k] {
+					t.Fatalf("CycleEdges() missing %q, got %v", k, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+// Package graph is the dependency-graph data model: file-provenanced edges,
+// plus the analyses run over them (cycle detection via Tarjan's SCC
+// algorithm, fan-in/fan-out, and unused go.mod requires).
+package graph
+
+import "slices"
+
+// Graph is a dependency graph with file-level provenance: for every edge
+// between two packages, the files in the importing package responsible for
+// it are kept, so a renderer can answer "which file pulled this in?".
+type Graph struct {
+	// Edges maps an importing package to the packages it imports, each
+	// with the (deduplicated, sorted) files that import it.
+	Edges map[string]map[string][]string
+
+	// Modules maps a package to the module it belongs to, when known, so a
+	// renderer can cluster nodes by owning module. Every walked package
+	// whose module was discovered gets an entry, in single- and
+	// multi-repo scans alike; imported packages outside the walked trees
+	// have none.
+	Modules map[string]string `json:",omitempty"`
+}
+
+// New returns an empty Graph ready for AddEdge calls.
+func New() Graph {
+	return Graph{
+		Edges:   make(map[string]map[string][]string),
+		Modules: make(map[string]string),
+	}
+}
+
+// SetModule records that pkg belongs to module.
+func (g Graph) SetModule(pkg, module string) {
+	g.Modules[pkg] = module
+}
+
+// Merge adds every edge and module tag from other into g, returning g.
+func (g Graph) Merge(other Graph) Graph {
+	for pkg, deps := range other.Edges {
+		g.AddNode(pkg)
+		for dep, files := range deps {
+			for _, file := range files {
+				g.AddEdge(pkg, dep, file)
+			}
+		}
+	}
+	for pkg, module := range other.Modules {
+		g.SetModule(pkg, module)
+	}
+	return g
+}
+
+// AddNode ensures pkg exists in the graph even if it has no outgoing
+// edges, so leaf packages still render as nodes.
+func (g Graph) AddNode(pkg string) {
+	if _, ok := g.Edges[pkg]; !ok {
+		g.Edges[pkg] = make(map[string][]string)
+	}
+}
+
+// AddEdge records that file, which lives in pkg, imports dependancy.
+func (g Graph) AddEdge(pkg, dependancy, file string) {
+	g.AddNode(pkg)
+	g.AddNode(dependancy)
+	g.Edges[pkg][dependancy] = append(g.Edges[pkg][dependancy], file)
+}
+
+// Normalize sorts and dedupes the file list on every edge. Call once after
+// the graph has been fully built.
+func (g Graph) Normalize() {
+	for _, deps := range g.Edges {
+		for dep, files := range deps {
+			slices.Sort(files)
+			deps[dep] = slices.Compact(files)
+		}
+	}
+}
+
+// Packages returns every package name that appears in the graph, sorted.
+func (g Graph) Packages() []string {
+	names := make([]string, 0, len(g.Edges))
+	for pkg := range g.Edges {
+		names = append(names, pkg)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Files returns the files in pkg that import dependancy, or nil if there is
+// no such edge.
+func (g Graph) Files(pkg, dependancy string) []string {
+	return g.Edges[pkg][dependancy]
+}
@@ -1,205 +1,367 @@
 package main
 
 import (
-	"bytes"
-	"fmt"
+	"context"
+	"encoding/json"
+	"flag"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"io"
 	"io/fs"
 	"log"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"runtime"
 	"strings"
 
-	"slices"
-
-	graphviz "github.com/awalterschulze/gographviz"
-	"github.com/go-git/go-billy/v5/memfs"
+	billy "github.com/go-git/go-billy/v5"
 	"github.com/go-git/go-billy/v5/util"
-	"github.com/go-git/go-git/v5"
-	"github.com/go-git/go-git/v5/plumbing"
-	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
-	"github.com/go-git/go-git/v5/storage/memory"
 	"golang.org/x/mod/modfile"
+
+	"github.com/suessflorian/go-dep-visual/cache"
+	"github.com/suessflorian/go-dep-visual/graph"
+	"github.com/suessflorian/go-dep-visual/render"
+	"github.com/suessflorian/go-dep-visual/repofetch"
+	"github.com/suessflorian/go-dep-visual/workspace"
 )
 
+// buildOptions controls which files in a repository are considered part of
+// the build: the platform //go:build constraints and _GOOS_GOARCH filename
+// suffixes are evaluated against, and whether _test.go files are walked.
+type buildOptions struct {
+	GOOS         string
+	GOARCH       string
+	BuildTags    []string
+	IncludeTests bool
+}
+
 func main() {
-	cmd := exec.Command("dot", "-V")
-	if err := cmd.Run(); err != nil {
-		log.Fatalf("Graphviz is not installed: %v", err)
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		serve(os.Args[2:])
+		return
 	}
+	renderToFile(os.Args[1:])
+}
+
+// fetchFlags registers the flags shared by every subcommand: how to reach
+// each repository and whether to consult a cache.
+func fetchFlags(flags *flag.FlagSet) (auth, tokenEnv, keyFile, keyPassphrase, branch, cacheURI *string) {
+	auth = flags.String("auth", string(repofetch.AuthAnonymous), "auth strategy for remote URLs: anonymous, token, agent, key")
+	tokenEnv = flags.String("token-env", "GITHUB_TOKEN", "environment variable holding the PAT when -auth=token")
+	keyFile = flags.String("key-file", filepath.Join(os.Getenv("HOME"), ".ssh", "id_rsa"), "private key file when -auth=key")
+	keyPassphrase = flags.String("key-passphrase", "", "passphrase for -key-file when -auth=key")
+	branch = flags.String("branch", "", "branch to check out (defaults to the remote's HEAD branch, ignored in multi-repo/go.work mode)")
+	cacheURI = flags.String("cache", "", "cache backend for parsed graphs keyed by commit SHA (cloned repos are not stored), e.g. file://./.dep-cache, s3://bucket, gs://bucket")
+	return
+}
 
-	if len(os.Args) < 2 {
-		log.Fatal("Please provide a directory path")
+// buildFlags registers the flags controlling which files are walked: the
+// target GOOS/GOARCH/tags that build constraints are evaluated against, and
+// whether _test.go files are included.
+func buildFlags(flags *flag.FlagSet) (goos, goarch, buildTags *string, includeTests *bool) {
+	goos = flags.String("goos", runtime.GOOS, "GOOS to evaluate build constraints against")
+	goarch = flags.String("goarch", runtime.GOARCH, "GOARCH to evaluate build constraints against")
+	buildTags = flags.String("build-tags", "", "comma-separated build tags to honour, as with go build -tags")
+	includeTests = flags.Bool("include-tests", false, "also walk _test.go files, rendering test-only edges with a distinct style")
+	return
+}
+
+func renderToFile(args []string) {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	auth, tokenEnv, keyFile, keyPassphrase, branch, cacheURI := fetchFlags(flags)
+	goos, goarch, buildTags, includeTests := buildFlags(flags)
+	format := flags.String("format", string(render.FormatPDF), "output format: svg, png, pdf, json")
+	out := flags.String("out", "graph", "output file path (extension is added automatically)")
+	collapseStdlib := flags.Bool("collapse-stdlib", false, "merge standard library packages into a single node")
+	modulePrefix := flags.String("module-prefix", "", "only render packages (and their neighbours) under this import path prefix")
+	highlightCycles := flags.Bool("highlight-cycles", false, "colour edges that sit on an import cycle")
+	showUnused := flags.Bool("show-unused", false, "dash edges into go.mod requires that nothing imports")
+	report := flags.String("report", "", "also write a machine-readable JSON analysis report (cycles, fan-in/out, unused requires) to this path")
+	if err := flags.Parse(args); err != nil {
+		log.Fatal(err)
 	}
 
-	url, err := convertHTTPtoSSH(os.Args[1])
-	if err != nil {
-		log.Fatalf("Please provide valid https:// link to your github profile: %s", err.Error())
+	targets := parseTargets(flags.Args())
+
+	opts := render.Options{
+		Format:          render.Format(*format),
+		Out:             *out + "." + *format,
+		CollapseStdlib:  *collapseStdlib,
+		ModulePrefix:    *modulePrefix,
+		HighlightCycles: *highlightCycles,
+	}
+	if opts.Format != render.FormatJSON {
+		cmd := exec.Command("dot", "-V")
+		if err := cmd.Run(); err != nil {
+			log.Fatalf("Graphviz is not installed: %v", err)
+		}
 	}
 
-	pemFile, err := os.Open(filepath.Join(os.Getenv("HOME"), "/.ssh/id_rsa"))
-	if err != nil {
-		log.Fatal(err)
+	buildOpts := buildOptions{GOOS: *goos, GOARCH: *goarch, BuildTags: splitTags(*buildTags), IncludeTests: *includeTests}
+	g, modFile := buildGraph(targets, *auth, *tokenEnv, *keyFile, *keyPassphrase, *branch, *cacheURI, buildOpts)
+
+	analysis := graph.Analyze(g, modFile)
+	if *showUnused {
+		opts.UnusedRequires = analysis.UnusedRequires
+	}
+	if *report != "" {
+		writeReport(*report, analysis)
 	}
 
-	buf := new(bytes.Buffer)
-	if _, err := buf.ReadFrom(pemFile); err != nil {
-		log.Fatal(err)
+	if err := render.Render(g, opts); err != nil {
+		log.Fatalf("Error rendering graph: %s", err)
 	}
+}
 
-	// attempt to use systems SSH keys
-	sshAuth, err := ssh.NewPublicKeys("git", buf.Bytes(), "")
-	if err != nil {
+func serve(args []string) {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	auth, tokenEnv, keyFile, keyPassphrase, branch, cacheURI := fetchFlags(flags)
+	goos, goarch, buildTags, includeTests := buildFlags(flags)
+	addr := flags.String("addr", ":8080", "address to serve the interactive graph on")
+	if err := flags.Parse(args); err != nil {
 		log.Fatal(err)
 	}
 
-	repo, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
-		URL:           url,
-		Auth:          sshAuth,
-		ReferenceName: plumbing.NewBranchReferenceName("master"), // TODO: assumes main, fallback to master?
-		Depth:         1,
-	})
+	targets := parseTargets(flags.Args())
+
+	buildOpts := buildOptions{GOOS: *goos, GOARCH: *goarch, BuildTags: splitTags(*buildTags), IncludeTests: *includeTests}
+	g, _ := buildGraph(targets, *auth, *tokenEnv, *keyFile, *keyPassphrase, *branch, *cacheURI, buildOpts)
+
+	if err := render.Serve(g, *addr); err != nil {
+		log.Fatalf("Error serving graph: %s", err)
+	}
+}
+
+func splitTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
+func parseTargets(args []string) []workspace.Target {
+	if len(args) < 1 {
+		log.Fatal("Please provide one or more repository URLs/paths, or a single go.work file")
+	}
+	targets, err := workspace.ParseArgs(args)
 	if err != nil {
-		log.Fatalf("Error cloning repository: %s", err)
+		log.Fatalf("Error parsing targets: %s", err)
 	}
+	return targets
+}
 
-	tree, err := repo.Worktree()
+func writeReport(path string, report graph.Report) {
+	data, err := json.MarshalIndent(report, "", "  ")
 	if err != nil {
-		log.Fatal(err)
+		log.Fatalf("Error marshalling report: %s", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Fatalf("Error writing report to %s: %s", path, err)
+	}
+}
+
+// buildGraph fetches every target and merges their dependency graphs into
+// one. modFile is only returned when scanning a single target (the common
+// case), since go.mod-based analysis doesn't generalize cleanly across
+// multiple top-level modules.
+func buildGraph(targets []workspace.Target, auth, tokenEnv, keyFile, keyPassphrase, branch, cacheURI string, buildOpts buildOptions) (graph.Graph, *modfile.File) {
+	merged := graph.New()
+
+	var soleModFile *modfile.File
+	for i, target := range targets {
+		fetchOpts := target.Fetch
+		fetchOpts.Auth = repofetch.AuthMode(auth)
+		fetchOpts.TokenEnv = tokenEnv
+		fetchOpts.KeyPath = keyFile
+		fetchOpts.KeyPassphrase = keyPassphrase
+		if len(targets) == 1 {
+			fetchOpts.Branch = branch
+		}
+
+		g, modFile := buildTargetGraph(target.Label, fetchOpts, cacheURI, buildOpts)
+		merged = merged.Merge(g)
+		if i == 0 {
+			soleModFile = modFile
+		} else {
+			soleModFile = nil
+		}
 	}
 
-	var moduleName string
+	return merged, soleModFile
+}
 
-	err = util.Walk(tree.Filesystem, ".", func(path string, info fs.FileInfo, err error) error {
+// buildTargetGraph resolves fetchOpts (consulting cacheURI if set) into a
+// file-provenanced dependency graph for a single repository, tagging every
+// package with its owning (nested) module. modFile is nil on a cache hit,
+// since go.mod isn't re-read then.
+func buildTargetGraph(label string, fetchOpts repofetch.Options, cacheURI string, buildOpts buildOptions) (graph.Graph, *modfile.File) {
+	ctx := context.Background()
+
+	var store cache.Storage
+	if cacheURI != "" {
+		var err error
+		store, err = cache.Open(cacheURI)
 		if err != nil {
-			return err
+			log.Fatalf("Error opening cache: %s", err)
 		}
-		if strings.HasSuffix(path, "go.mod") {
-			file, err := tree.Filesystem.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
+	}
 
-			buf := new(bytes.Buffer)
-			if _, err := buf.ReadFrom(file); err != nil {
-				return err
-			}
+	// Local paths are never cached: the working copy can be dirty between
+	// runs, so the commit SHA alone isn't a safe cache key for it.
+	var sha string
+	if store != nil && !repofetch.IsLocalPath(fetchOpts.URL) {
+		resolvedBranch, resolvedSHA, err := repofetch.ResolveHead(fetchOpts)
+		if err != nil {
+			log.Fatalf("Error resolving HEAD for %s: %s", label, err)
+		}
+		fetchOpts.Branch = resolvedBranch
+		sha = resolvedSHA
+	}
 
-			modFile, err := modfile.Parse(path, buf.Bytes(), nil)
-			if err != nil {
-				log.Fatalf("failed to parse go.mod file: %v", err)
-			}
+	if sha != "" {
+		entry, ok, err := store.Get(ctx, sha)
+		if err != nil {
+			log.Fatalf("Error reading cache for %s: %s", label, err)
+		}
+		if ok {
+			return entry.Graph, nil
+		}
+	}
 
-			moduleName = modFile.Module.Mod.Path
-			return nil
+	repo, err := repofetch.Fetch(fetchOpts)
+	if err != nil {
+		log.Fatalf("Error fetching %s: %s", label, err)
+	}
+
+	if store != nil && sha == "" && !repofetch.IsLocalPath(fetchOpts.URL) {
+		if head, err := repo.Head(); err == nil {
+			sha = head.Hash().String()
 		}
+	}
 
-		return nil
-	})
+	tree, err := repo.Worktree()
+	if err != nil {
+		log.Fatal(err)
+	}
 
+	mods, err := workspace.Modules(tree.Filesystem)
 	if err != nil {
-		log.Fatalf("failed to search the git work tree for the `go.mod` file: %s", err.Error())
+		log.Fatalf("failed to discover modules in %s: %s", label, err)
 	}
 
-	dependancyGraph := make(map[string][]string)
+	var rootModFile *modfile.File
+	for _, mod := range mods {
+		if mod.Dir == "." {
+			data, err := util.ReadFile(tree.Filesystem, "go.mod")
+			if err == nil {
+				rootModFile, _ = modfile.Parse("go.mod", data, nil)
+			}
+			break
+		}
+	}
+
+	buildCtx := newBuildContext(tree.Filesystem, buildOpts)
+	dependancyGraph := graph.New()
 
-	err = util.Walk(tree.Filesystem, ".", func(path string, info fs.FileInfo, err error) error {
+	err = util.Walk(tree.Filesystem, ".", func(filePath string, info fs.FileInfo, err error) error {
+		if workspace.IsGitDir(filePath) {
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
 		if err != nil {
 			return err
 		}
 
-		if filepath.Ext(path) == ".go" {
-			file, err := tree.Filesystem.Open(path)
-			if err != nil {
-				return err
-			}
-			defer file.Close()
+		match, err := shouldWalkFile(buildCtx, filePath, buildOpts.IncludeTests)
+		if err != nil {
+			return err
+		}
+		if !match {
+			return nil
+		}
 
-			name := getPackageName(moduleName, path)
+		file, err := tree.Filesystem.Open(filePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
 
-			if _, ok := dependancyGraph[name]; !ok {
-				dependancyGraph[name] = make([]string, 0)
-			}
+		name := workspace.PackageName(mods, filePath)
+		dependancyGraph.AddNode(name)
+		if mod, ok := workspace.Nearest(mods, filePath); ok {
+			dependancyGraph.SetModule(name, mod.Path)
+		}
 
-			source, err := parser.ParseFile(token.NewFileSet(), path, file, parser.ImportsOnly)
-			if err != nil {
-				log.Fatalf("Failed to parse file: %v", err)
-			}
+		source, err := parser.ParseFile(token.NewFileSet(), filePath, file, parser.ImportsOnly)
+		if err != nil {
+			log.Fatalf("Failed to parse file: %v", err)
+		}
 
-			for _, importSpec := range source.Imports {
-				dependancy := importSpec.Path.Value[1 : len(importSpec.Path.Value)-1]
-				if _, ok := dependancyGraph[dependancy]; !ok {
-					dependancyGraph[dependancy] = make([]string, 0)
-				}
-				dependancyGraph[name] = append(dependancyGraph[name], dependancy)
-			}
+		for _, importSpec := range source.Imports {
+			dependancy := importSpec.Path.Value[1 : len(importSpec.Path.Value)-1]
+			dependancyGraph.AddEdge(name, dependancy, filePath)
 		}
 
 		return nil
 	})
 
 	if err != nil {
-		log.Fatal("failed to walk directory provided: %w", err)
-	}
-
-	for _, deps := range dependancyGraph {
-		slices.Sort(deps)
-		slices.Compact(deps)
+		log.Fatalf("failed to walk %s: %s", label, err.Error())
 	}
 
-	graph := graphviz.NewGraph()
-	graph.Directed = true
-
-	seen := make(map[string]bool)
-
-	for module, dependancies := range dependancyGraph {
-		if !seen[module] {
-			seen[module] = true
-			if err := graph.AddNode("G", fmt.Sprintf("%q", module), nil); err != nil {
-				panic(err)
-			}
-		}
-
-		for _, dependancy := range dependancies {
-			if !seen[dependancy] {
-				seen[dependancy] = true
-				if err := graph.AddNode("G", fmt.Sprintf("%q", dependancy), nil); err != nil {
-					panic(err)
-				}
-			}
+	dependancyGraph.Normalize()
 
-			if err := graph.AddEdge(fmt.Sprintf("%q", dependancy), fmt.Sprintf("%q", module), true, nil); err != nil {
-				panic(err)
-			}
+	if store != nil && sha != "" {
+		if err := store.Put(ctx, sha, &cache.Entry{Graph: dependancyGraph}); err != nil {
+			log.Printf("warning: failed to write cache entry for %s: %s", label, err)
 		}
 	}
 
-	if err := os.WriteFile("graph.dot", []byte(graph.String()), fs.ModePerm); err != nil {
-		log.Fatal("couldn't write graph.dot file")
-	}
-
-	cmd = exec.Command("dot", "-Tpdf", "graph.dot", "-o", "graph.pdf")
-	if err := cmd.Run(); err != nil {
-		panic(err)
-	}
+	return dependancyGraph, rootModFile
 }
 
-func convertHTTPtoSSH(httpURL string) (string, error) {
-	if !strings.HasPrefix(httpURL, "https://") {
-		return "", fmt.Errorf("invalid URL format")
+// shouldWalkFile reports whether filePath belongs in the dependency graph:
+// files not ending in .go never do, _test.go files are excluded unless
+// includeTests is set, and every other file must satisfy buildCtx's GOOS/GOARCH/build-tag
+// constraints (both //go:build comments and _GOOS_GOARCH filename suffixes).
+func shouldWalkFile(buildCtx *build.Context, filePath string, includeTests bool) (bool, error) {
+	if filepath.Ext(filePath) != ".go" {
+		return false, nil
+	}
+	if strings.HasSuffix(filePath, "_test.go") && !includeTests {
+		return false, nil
 	}
 
-	sshURL := strings.Replace(httpURL, "https://", "git@", 1)
-	sshURL = strings.Replace(sshURL, "/", ":", 1)
-
-	return sshURL, nil
+	dir, name := path.Split(filepath.ToSlash(filePath))
+	return buildCtx.MatchFile(dir, name)
 }
 
-func getPackageName(modulePath, relativeFilePath string) string {
-	// Join the module path and the directory of the relative file path
-	fullPath := filepath.Join(modulePath, filepath.Dir(relativeFilePath))
-	return fullPath
+// newBuildContext returns a go/build.Context that evaluates build
+// constraints (both //go:build comments and _GOOS_GOARCH filename suffixes)
+// against opts, reading files out of fsys rather than the local disk so it
+// works against an in-memory clone as well as a local working copy.
+func newBuildContext(fsys billy.Filesystem, opts buildOptions) *build.Context {
+	ctx := build.Default
+	ctx.GOOS = opts.GOOS
+	ctx.GOARCH = opts.GOARCH
+	ctx.BuildTags = opts.BuildTags
+	ctx.JoinPath = path.Join
+	ctx.IsAbsPath = path.IsAbs
+	ctx.IsDir = func(p string) bool {
+		info, err := fsys.Stat(p)
+		return err == nil && info.IsDir()
+	}
+	ctx.ReadDir = func(dir string) ([]fs.FileInfo, error) {
+		return fsys.ReadDir(dir)
+	}
+	ctx.OpenFile = func(p string) (io.ReadCloser, error) {
+		return fsys.Open(p)
+	}
+	ctx.HasSubdir = func(root, dir string) (rel string, ok bool) { return "", false }
+	return &ctx
 }
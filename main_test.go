@@ -0,0 +1,104 @@
+package main
+
+import (
+	"testing"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+)
+
+func writeFile(t *testing.T, fsys billy.Filesystem, path, content string) {
+	t.Helper()
+	f, err := fsys.Create(path)
+	if err != nil {
+		t.Fatalf("creating %s: %s", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte(content)); err != nil {
+		t.Fatalf("writing %s: %s", path, err)
+	}
+}
+
+func TestShouldWalkFile(t *testing.T) {
+	tests := map[string]struct {
+		path         string
+		content      string
+		includeTests bool
+		want         bool
+	}{
+		"plain file with no constraints matches": {
+			path:    "foo.go",
+			content: "package foo\n",
+			want:    true,
+		},
+		"non-go file never matches": {
+			path:    "README.md",
+			content: "hello\n",
+			want:    false,
+		},
+		"test file excluded by default": {
+			path:         "foo_test.go",
+			content:      "package foo\n",
+			includeTests: false,
+			want:         false,
+		},
+		"test file included when flag set": {
+			path:         "foo_test.go",
+			content:      "package foo\n",
+			includeTests: true,
+			want:         true,
+		},
+		"matching GOOS filename suffix": {
+			path:    "foo_linux.go",
+			content: "package foo\n",
+			want:    true,
+		},
+		"mismatched GOOS filename suffix": {
+			path:    "foo_darwin.go",
+			content: "package foo\n",
+			want:    false,
+		},
+		"matching go:build constraint": {
+			path:    "foo.go",
+			content: "//go:build linux\n\npackage foo\n",
+			want:    true,
+		},
+		"mismatched go:build constraint": {
+			path:    "foo.go",
+			content: "//go:build darwin\n\npackage foo\n",
+			want:    false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			fsys := memfs.New()
+			writeFile(t, fsys, tc.path, tc.content)
+
+			buildCtx := newBuildContext(fsys, buildOptions{GOOS: "linux", GOARCH: "amd64"})
+
+			got, err := shouldWalkFile(buildCtx, tc.path, tc.includeTests)
+			if err != nil {
+				t.Fatalf("shouldWalkFile(%q) returned error: %s", tc.path, err)
+			}
+			if got != tc.want {
+				t.Fatalf("shouldWalkFile(%q) = %v, want %v", tc.path, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestShouldWalkFileHonoursBuildTags(t *testing.T) {
+	fsys := memfs.New()
+	writeFile(t, fsys, "foo.go", "//go:build special\n\npackage foo\n")
+
+	withoutTag := newBuildContext(fsys, buildOptions{GOOS: "linux", GOARCH: "amd64"})
+	if got, err := shouldWalkFile(withoutTag, "foo.go", false); err != nil || got {
+		t.Fatalf("shouldWalkFile() without -build-tags = (%v, %v), want (false, nil)", got, err)
+	}
+
+	withTag := newBuildContext(fsys, buildOptions{GOOS: "linux", GOARCH: "amd64", BuildTags: []string{"special"}})
+	if got, err := shouldWalkFile(withTag, "foo.go", false); err != nil || !got {
+		t.Fatalf("shouldWalkFile() with -build-tags=special = (%v, %v), want (true, nil)", got, err)
+	}
+}
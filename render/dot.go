@@ -0,0 +1,142 @@
+package render
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	graphviz "github.com/awalterschulze/gographviz"
+
+	"github.com/suessflorian/go-dep-visual/graph"
+)
+
+// applyFilters collapses stdlib nodes and restricts the graph to packages
+// matching opts.ModulePrefix (plus their direct neighbours), per Options.
+func applyFilters(g graph.Graph, opts Options) graph.Graph {
+	out := graph.New()
+
+	for from, deps := range g.Edges {
+		for to, files := range deps {
+			if opts.ModulePrefix != "" &&
+				!strings.HasPrefix(from, opts.ModulePrefix) &&
+				!strings.HasPrefix(to, opts.ModulePrefix) {
+				continue
+			}
+
+			fromName, toName := displayName(from, opts), displayName(to, opts)
+			for _, file := range files {
+				out.AddEdge(fromName, toName, file)
+			}
+			if module, ok := g.Modules[from]; ok && fromName == from {
+				out.SetModule(fromName, module)
+			}
+			if module, ok := g.Modules[to]; ok && toName == to {
+				out.SetModule(toName, module)
+			}
+		}
+	}
+
+	out.Normalize()
+	return out
+}
+
+func displayName(pkg string, opts Options) string {
+	if opts.CollapseStdlib && isStdlib(pkg) {
+		return "stdlib"
+	}
+	return pkg
+}
+
+// buildDot renders g (after filtering) into Graphviz DOT syntax, colouring
+// cycle edges red and dashing edges into unused go.mod requires.
+func buildDot(g graph.Graph, opts Options) (string, error) {
+	g = applyFilters(g, opts)
+
+	var cycles map[string]bool
+	if opts.HighlightCycles {
+		cycles = graph.CycleEdges(g)
+	}
+
+	dotGraph := graphviz.NewGraph()
+	dotGraph.Directed = true
+
+	clusters := make(map[string]string) // module path -> cluster subgraph name
+	modules := make([]string, 0)
+	for _, module := range g.Modules {
+		if !slices.Contains(modules, module) {
+			modules = append(modules, module)
+		}
+	}
+	slices.Sort(modules)
+	for i, module := range modules {
+		name := fmt.Sprintf("cluster_%d", i)
+		clusters[module] = name
+		if err := dotGraph.AddSubGraph("G", name, map[string]string{"label": fmt.Sprintf("%q", module)}); err != nil {
+			return "", err
+		}
+	}
+
+	seen := make(map[string]bool)
+	ensureNode := func(name string) error {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		parent := "G"
+		if module, ok := g.Modules[name]; ok {
+			parent = clusters[module]
+		}
+		return dotGraph.AddNode(parent, fmt.Sprintf("%q", name), nil)
+	}
+
+	for _, pkg := range g.Packages() {
+		if err := ensureNode(pkg); err != nil {
+			return "", err
+		}
+	}
+
+	for from, deps := range g.Edges {
+		for to, files := range deps {
+			attrs := map[string]string{}
+			if cycles[from+" -> "+to] {
+				attrs["color"] = "red"
+			}
+			if isUnusedRequire(to, opts.UnusedRequires) {
+				attrs["style"] = "dashed"
+			}
+			if isTestOnly(files) {
+				attrs["style"] = "dotted"
+				attrs["color"] = "blue"
+			}
+			if err := dotGraph.AddEdge(fmt.Sprintf("%q", to), fmt.Sprintf("%q", from), true, attrs); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	return dotGraph.String(), nil
+}
+
+func isUnusedRequire(pkg string, unused []string) bool {
+	for _, u := range unused {
+		if pkg == u || strings.HasPrefix(pkg, u+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// isTestOnly reports whether every file responsible for an edge is a
+// _test.go file, i.e. the dependency is only exercised by tests.
+func isTestOnly(files []string) bool {
+	if len(files) == 0 {
+		return false
+	}
+	for _, f := range files {
+		if !strings.HasSuffix(f, "_test.go") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,83 @@
+// Package render turns a Graph into output a human can look at: a static
+// SVG/PNG/PDF/JSON file via Render, or an interactive HTML view via Serve.
+package render
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+
+	"github.com/suessflorian/go-dep-visual/graph"
+)
+
+// Format is an output format supported by Render.
+type Format string
+
+const (
+	FormatSVG  Format = "svg"
+	FormatPNG  Format = "png"
+	FormatPDF  Format = "pdf"
+	FormatJSON Format = "json"
+)
+
+// Options configures how a Graph is rendered.
+type Options struct {
+	Format Format
+	// Out is the output file path.
+	Out string
+
+	// CollapseStdlib merges every standard-library package into a single
+	// "stdlib" node.
+	CollapseStdlib bool
+	// ModulePrefix, if set, keeps only packages (and their neighbours)
+	// whose import path has this prefix.
+	ModulePrefix string
+	// HighlightCycles colours edges that sit on an import cycle red.
+	HighlightCycles bool
+	// UnusedRequires marks edges into these packages as dashed (go.mod
+	// requires that nothing actually imports).
+	UnusedRequires []string
+}
+
+// Render writes g to opts.Out in opts.Format.
+func Render(g graph.Graph, opts Options) error {
+	g.Normalize()
+
+	if opts.Format == FormatJSON {
+		return renderJSON(g, opts)
+	}
+	return renderGraphviz(g, opts)
+}
+
+func renderJSON(g graph.Graph, opts Options) error {
+	g = applyFilters(g, opts)
+	data, err := json.MarshalIndent(g.Edges, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshalling graph: %w", err)
+	}
+	if err := os.WriteFile(opts.Out, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", opts.Out, err)
+	}
+	return nil
+}
+
+func renderGraphviz(g graph.Graph, opts Options) error {
+	dot, err := buildDot(g, opts)
+	if err != nil {
+		return fmt.Errorf("building dot graph: %w", err)
+	}
+
+	dotPath := opts.Out + ".dot"
+	if err := os.WriteFile(dotPath, []byte(dot), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", dotPath, err)
+	}
+	defer os.Remove(dotPath)
+
+	cmd := exec.Command("dot", "-T"+string(opts.Format), dotPath, "-o", opts.Out)
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("running dot: %w", err)
+	}
+	return nil
+}
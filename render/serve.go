@@ -0,0 +1,184 @@
+package render
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"github.com/suessflorian/go-dep-visual/graph"
+)
+
+type graphNode struct {
+	ID     string `json:"id"`
+	Stdlib bool   `json:"stdlib"`
+	Module string `json:"module,omitempty"`
+}
+
+type graphLink struct {
+	Source string `json:"source"`
+	Target string `json:"target"`
+	Cycle  bool   `json:"cycle"`
+	Test   bool   `json:"test"`
+}
+
+type graphPayload struct {
+	Nodes []graphNode `json:"nodes"`
+	Links []graphLink `json:"links"`
+}
+
+type importer struct {
+	From  string   `json:"from"`
+	Files []string `json:"files"`
+}
+
+// Serve starts an HTTP server on addr presenting an interactive view of g.
+// Query params on /api/graph (prefix, collapseStdlib, highlightCycles)
+// re-filter the graph live without restarting the server.
+func Serve(g graph.Graph, addr string) error {
+	g.Normalize()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", serveIndex)
+	mux.HandleFunc("/api/graph", func(w http.ResponseWriter, r *http.Request) {
+		opts := Options{
+			ModulePrefix:    r.URL.Query().Get("prefix"),
+			CollapseStdlib:  r.URL.Query().Get("collapseStdlib") == "true",
+			HighlightCycles: r.URL.Query().Get("highlightCycles") == "true",
+		}
+		writeJSON(w, toPayload(applyFilters(g, opts), opts))
+	})
+	mux.HandleFunc("/api/importers", func(w http.ResponseWriter, r *http.Request) {
+		pkg := r.URL.Query().Get("node")
+		importers := make([]importer, 0)
+		for from, deps := range g.Edges {
+			if files, ok := deps[pkg]; ok {
+				importers = append(importers, importer{From: from, Files: files})
+			}
+		}
+		writeJSON(w, importers)
+	})
+
+	log.Printf("serving dependency graph on http://%s", addr)
+	return http.ListenAndServe(addr, mux)
+}
+
+func toPayload(g graph.Graph, opts Options) graphPayload {
+	var cycles map[string]bool
+	if opts.HighlightCycles {
+		cycles = graph.CycleEdges(g)
+	}
+
+	payload := graphPayload{}
+	for _, pkg := range g.Packages() {
+		payload.Nodes = append(payload.Nodes, graphNode{ID: pkg, Stdlib: isStdlib(pkg), Module: g.Modules[pkg]})
+	}
+	for from, deps := range g.Edges {
+		for to, files := range deps {
+			payload.Links = append(payload.Links, graphLink{
+				Source: from,
+				Target: to,
+				Cycle:  cycles[from+" -> "+to],
+				Test:   isTestOnly(files),
+			})
+		}
+	}
+	return payload
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func serveIndex(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html")
+	w.Write([]byte(indexHTML))
+}
+
+const indexHTML = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>go-dep-visual</title>
+  <script src="https://d3js.org/d3.v7.min.js"></script>
+  <style>
+    body { font-family: sans-serif; margin: 0; display: flex; }
+    #controls { padding: 1em; width: 260px; }
+    #graph { flex: 1; }
+    #importers { white-space: pre-wrap; font-size: 0.8em; }
+    line { stroke: #999; stroke-opacity: 0.6; }
+    line.cycle { stroke: red; stroke-opacity: 0.9; }
+    line.test { stroke: blue; stroke-dasharray: 4 2; }
+    circle { fill: steelblue; cursor: pointer; }
+    circle.stdlib { fill: #aaa; }
+  </style>
+</head>
+<body>
+  <div id="controls">
+    <label>Module prefix <input id="prefix"></label><br>
+    <label><input type="checkbox" id="collapseStdlib"> Collapse stdlib</label><br>
+    <label><input type="checkbox" id="highlightCycles"> Highlight cycles</label><br>
+    <button id="apply">Apply</button>
+    <h4>Importers</h4>
+    <div id="importers"></div>
+  </div>
+  <svg id="graph" width="100%" height="100%"></svg>
+  <script>
+    const svg = d3.select("#graph");
+    const width = window.innerWidth - 260, height = window.innerHeight;
+
+    function load() {
+      const params = new URLSearchParams({
+        prefix: document.getElementById("prefix").value,
+        collapseStdlib: document.getElementById("collapseStdlib").checked,
+        highlightCycles: document.getElementById("highlightCycles").checked,
+      });
+      fetch("/api/graph?" + params).then(r => r.json()).then(draw);
+    }
+
+    function draw(graph) {
+      svg.selectAll("*").remove();
+
+      const sim = d3.forceSimulation(graph.nodes)
+        .force("link", d3.forceLink(graph.links).id(d => d.id).distance(80))
+        .force("charge", d3.forceManyBody().strength(-200))
+        .force("center", d3.forceCenter(width / 2, height / 2));
+
+      const link = svg.append("g").selectAll("line")
+        .data(graph.links).join("line")
+        .attr("class", d => d.cycle ? "cycle" : d.test ? "test" : "");
+
+      const node = svg.append("g").selectAll("circle")
+        .data(graph.nodes).join("circle")
+        .attr("r", 6)
+        .attr("class", d => d.stdlib ? "stdlib" : "")
+        .on("click", (event, d) => showImporters(d.id))
+        .call(d3.drag()
+          .on("start", (event, d) => { if (!event.active) sim.alphaTarget(0.3).restart(); d.fx = d.x; d.fy = d.y; })
+          .on("drag", (event, d) => { d.fx = event.x; d.fy = event.y; })
+          .on("end", (event, d) => { if (!event.active) sim.alphaTarget(0); d.fx = null; d.fy = null; }));
+
+      node.append("title").text(d => d.id);
+
+      sim.on("tick", () => {
+        link
+          .attr("x1", d => d.source.x).attr("y1", d => d.source.y)
+          .attr("x2", d => d.target.x).attr("y2", d => d.target.y);
+        node.attr("cx", d => d.x).attr("cy", d => d.y);
+      });
+    }
+
+    function showImporters(node) {
+      fetch("/api/importers?node=" + encodeURIComponent(node)).then(r => r.json()).then(importers => {
+        document.getElementById("importers").textContent = JSON.stringify(importers, null, 2);
+      });
+    }
+
+    document.getElementById("apply").addEventListener("click", load);
+    load();
+  </script>
+</body>
+</html>
+`
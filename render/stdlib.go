@@ -0,0 +1,11 @@
+package render
+
+import "strings"
+
+// isStdlib reports whether pkg looks like a standard library import: no
+// dot in its first path segment (mirrors the heuristic `go list` itself
+// uses to separate std from module paths).
+func isStdlib(pkg string) bool {
+	first, _, _ := strings.Cut(pkg, "/")
+	return !strings.Contains(first, ".")
+}
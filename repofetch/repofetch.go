@@ -0,0 +1,190 @@
+// Package repofetch resolves a repository reference (a remote URL or a local
+// working copy) into a go-git repository, picking an auth strategy and a
+// default branch without the caller having to know either up front.
+package repofetch
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// AuthMode selects how Fetch authenticates against a remote.
+type AuthMode string
+
+const (
+	// AuthAnonymous performs no authentication, for public HTTPS remotes.
+	AuthAnonymous AuthMode = "anonymous"
+	// AuthToken sends a personal access token read from an environment
+	// variable as the HTTPS basic auth password.
+	AuthToken AuthMode = "token"
+	// AuthAgent delegates to the running SSH agent (SSH_AUTH_SOCK).
+	AuthAgent AuthMode = "agent"
+	// AuthKey reads an explicit private key file, optionally passphrase
+	// protected.
+	AuthKey AuthMode = "key"
+)
+
+// Options configures a single Fetch call.
+type Options struct {
+	// URL is either a remote (https:// or git@/ssh://) or a path to a
+	// local working copy on disk.
+	URL string
+
+	// Auth selects the strategy used for remote URLs. Ignored for local
+	// paths.
+	Auth AuthMode
+	// TokenEnv is the environment variable holding the PAT when Auth is
+	// AuthToken.
+	TokenEnv string
+	// KeyPath is the private key file used when Auth is AuthKey.
+	KeyPath string
+	// KeyPassphrase decrypts KeyPath when Auth is AuthKey.
+	KeyPassphrase string
+
+	// Branch pins the branch to check out. If empty, the remote's
+	// default branch is resolved via `ls-remote HEAD`.
+	Branch string
+}
+
+// Fetch resolves opts into a *git.Repository. Local filesystem paths are
+// opened in place so un-pushed working copies can be visualized; everything
+// else is shallow cloned into memory.
+func Fetch(opts Options) (*git.Repository, error) {
+	if IsLocalPath(opts.URL) {
+		repo, err := git.PlainOpen(opts.URL)
+		if err != nil {
+			return nil, fmt.Errorf("opening local repository: %w", err)
+		}
+		return repo, nil
+	}
+
+	auth, err := authFor(opts)
+	if err != nil {
+		return nil, fmt.Errorf("resolving auth: %w", err)
+	}
+
+	branch := opts.Branch
+	if branch == "" {
+		branch, _, err = resolveHead(opts.URL, auth, "")
+		if err != nil {
+			return nil, fmt.Errorf("detecting default branch: %w", err)
+		}
+	}
+
+	repo, err := git.Clone(memory.NewStorage(), memfs.New(), &git.CloneOptions{
+		URL:           opts.URL,
+		Auth:          auth,
+		ReferenceName: plumbing.NewBranchReferenceName(branch),
+		Depth:         1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cloning %s: %w", opts.URL, err)
+	}
+
+	return repo, nil
+}
+
+// ResolveHead performs a single `ls-remote` against opts.URL and returns the
+// branch that will be checked out (opts.Branch if set, otherwise the
+// remote's HEAD branch) along with the commit SHA it currently points at.
+// This lets callers make caching decisions before paying for a clone.
+func ResolveHead(opts Options) (branch, sha string, err error) {
+	auth, err := authFor(opts)
+	if err != nil {
+		return "", "", fmt.Errorf("resolving auth: %w", err)
+	}
+
+	return resolveHead(opts.URL, auth, opts.Branch)
+}
+
+// IsLocalPath reports whether url names a directory on disk rather than a
+// remote.
+func IsLocalPath(url string) bool {
+	if strings.HasPrefix(url, "https://") || strings.HasPrefix(url, "http://") ||
+		strings.HasPrefix(url, "git@") || strings.HasPrefix(url, "ssh://") {
+		return false
+	}
+
+	info, err := os.Stat(url)
+	return err == nil && info.IsDir()
+}
+
+func authFor(opts Options) (transport.AuthMethod, error) {
+	switch opts.Auth {
+	case "", AuthAnonymous:
+		return nil, nil
+	case AuthToken:
+		if opts.TokenEnv == "" {
+			return nil, fmt.Errorf("token auth requires TokenEnv to name the environment variable holding the PAT")
+		}
+		token := os.Getenv(opts.TokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("environment variable %s is empty", opts.TokenEnv)
+		}
+		return &http.BasicAuth{Username: "git", Password: token}, nil
+	case AuthAgent:
+		auth, err := ssh.NewSSHAgentAuth("git")
+		if err != nil {
+			return nil, fmt.Errorf("connecting to SSH agent (is SSH_AUTH_SOCK set?): %w", err)
+		}
+		return auth, nil
+	case AuthKey:
+		if opts.KeyPath == "" {
+			return nil, fmt.Errorf("key auth requires KeyPath")
+		}
+		auth, err := ssh.NewPublicKeysFromFile("git", opts.KeyPath, opts.KeyPassphrase)
+		if err != nil {
+			return nil, fmt.Errorf("reading key %s: %w", opts.KeyPath, err)
+		}
+		return auth, nil
+	default:
+		return nil, fmt.Errorf("unknown auth mode %q", opts.Auth)
+	}
+}
+
+// resolveHead performs a single `ls-remote` against url and returns the
+// branch that will be checked out (pinned, if pinned is non-empty, otherwise
+// the remote's HEAD branch) along with the commit SHA it points at.
+func resolveHead(url string, auth transport.AuthMethod, pinned string) (branch, sha string, err error) {
+	remote := git.NewRemote(memory.NewStorage(), &config.RemoteConfig{
+		Name: "origin",
+		URLs: []string{url},
+	})
+
+	refs, err := remote.List(&git.ListOptions{Auth: auth})
+	if err != nil {
+		return "", "", err
+	}
+
+	branch = pinned
+	if branch == "" {
+		for _, ref := range refs {
+			if ref.Name() == plumbing.HEAD && ref.Type() == plumbing.SymbolicReference {
+				branch = ref.Target().Short()
+				break
+			}
+		}
+		if branch == "" {
+			return "", "", fmt.Errorf("remote did not advertise a symbolic HEAD")
+		}
+	}
+
+	branchRef := plumbing.NewBranchReferenceName(branch)
+	for _, ref := range refs {
+		if ref.Name() == branchRef && ref.Type() == plumbing.HashReference {
+			return branch, ref.Hash().String(), nil
+		}
+	}
+
+	return "", "", fmt.Errorf("remote does not have branch %q", branch)
+}
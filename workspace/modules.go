@@ -0,0 +1,156 @@
+package workspace
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"golang.org/x/mod/modfile"
+)
+
+// Module is one go.mod found while walking a repo's worktree.
+type Module struct {
+	// Path is the module's declared import path, after any alias from a
+	// sibling module's replace directive has been applied (see
+	// applyAliases).
+	Path string
+	// Dir is the module's root directory, relative to the worktree root
+	// ("." for the top-level module).
+	Dir string
+	// Replace maps an old module path (what importers' source still
+	// says) to what this module's go.mod redirects it to.
+	Replace map[string]string
+}
+
+// Modules walks fsys and returns every module it finds, deepest directory
+// first, so PackageName always matches the nearest enclosing module before
+// a shallower one.
+func Modules(fsys billy.Filesystem) ([]Module, error) {
+	var mods []Module
+
+	err := util.Walk(fsys, ".", func(path string, info fs.FileInfo, err error) error {
+		if IsGitDir(path) {
+			if info != nil && info.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if filepath.Base(path) != "go.mod" {
+			return nil
+		}
+
+		file, err := fsys.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		buf := new(bytes.Buffer)
+		if _, err := buf.ReadFrom(file); err != nil {
+			return err
+		}
+
+		modFile, err := modfile.Parse(path, buf.Bytes(), nil)
+		if err != nil {
+			return fmt.Errorf("parsing %s: %w", path, err)
+		}
+
+		replace := make(map[string]string, len(modFile.Replace))
+		for _, r := range modFile.Replace {
+			replace[r.Old.Path] = r.New.Path
+		}
+
+		mods = append(mods, Module{
+			Path:    modFile.Module.Mod.Path,
+			Dir:     filepath.Dir(path),
+			Replace: replace,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// "." is the shallowest of all, even though it is as long as a
+	// one-letter subdirectory.
+	depth := func(dir string) int {
+		if dir == "." {
+			return 0
+		}
+		return len(dir)
+	}
+	sort.Slice(mods, func(i, j int) bool { return depth(mods[i].Dir) > depth(mods[j].Dir) })
+	applyAliases(mods)
+	return mods, nil
+}
+
+// applyAliases rewrites a module's Path in place when some sibling
+// module's replace directive points a local directory at it: the fork then
+// reports itself under the upstream name its importers already use, so the
+// two collapse onto one graph node.
+func applyAliases(mods []Module) {
+	byDir := make(map[string]*Module, len(mods))
+	for i := range mods {
+		byDir[filepath.Clean(mods[i].Dir)] = &mods[i]
+	}
+
+	for _, r := range mods {
+		for old, new := range r.Replace {
+			if !isLocalReplace(new) {
+				continue
+			}
+			target := filepath.Clean(filepath.Join(r.Dir, new))
+			if m, ok := byDir[target]; ok {
+				m.Path = old
+			}
+		}
+	}
+}
+
+func isLocalReplace(path string) bool {
+	return strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../") || path == "."
+}
+
+// IsGitDir reports whether path names a .git entry. go-git's worktree
+// filesystem refuses to read any path with a .git component (its
+// CVE-2019-1353 protection), so every walker that might run against a local
+// working copy (the only case a real .git directory shows up) must skip it
+// rather than let the walk fail on go-dep-visual's own repository.
+func IsGitDir(path string) bool {
+	return filepath.Base(path) == ".git"
+}
+
+// Nearest returns the module enclosing relFilePath: the one with the
+// longest Dir that contains it. mods must be sorted deepest-first, as
+// returned by Modules.
+func Nearest(mods []Module, relFilePath string) (Module, bool) {
+	for _, m := range mods {
+		if m.Dir == "." || strings.HasPrefix(relFilePath, m.Dir+string(filepath.Separator)) {
+			return m, true
+		}
+	}
+	return Module{}, false
+}
+
+// PackageName returns the import path of the package containing
+// relFilePath, given the modules discovered in its worktree.
+func PackageName(mods []Module, relFilePath string) string {
+	mod, ok := Nearest(mods, relFilePath)
+	if !ok {
+		return filepath.ToSlash(filepath.Dir(relFilePath))
+	}
+
+	sub := relFilePath
+	if mod.Dir != "." {
+		sub = strings.TrimPrefix(relFilePath, mod.Dir+string(filepath.Separator))
+	}
+	return filepath.ToSlash(filepath.Join(mod.Path, filepath.Dir(sub)))
+}
@@ -0,0 +1,168 @@
+package workspace
+
+import (
+	"testing"
+
+	billy "github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+)
+
+func writeFile(t *testing.T, fsys billy.Filesystem, path, content string) {
+	t.Helper()
+	f, err := fsys.Create(path)
+	if err != nil {
+		t.Fatalf("creating %s: %s", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write([]byte(content)); err != nil {
+		t.Fatalf("writing %s: %s", path, err)
+	}
+}
+
+func TestModules(t *testing.T) {
+	fsys := memfs.New()
+	writeFile(t, fsys, "go.mod", "module github.com/acme/monorepo\n\ngo 1.21\n\nrequire github.com/upstream/lib v1.0.0\n\nreplace github.com/upstream/lib => ./fork\n")
+	writeFile(t, fsys, "fork/go.mod", "module github.com/acme/monorepo/fork\n\ngo 1.21\n")
+	writeFile(t, fsys, "pkg/sub/go.mod", "module github.com/acme/sub\n\ngo 1.21\n")
+
+	mods, err := Modules(fsys)
+	if err != nil {
+		t.Fatalf("Modules() error = %s", err)
+	}
+
+	// Deepest directory first.
+	for i := 1; i < len(mods); i++ {
+		if len(mods[i-1].Dir) < len(mods[i].Dir) {
+			t.Fatalf("Modules() not sorted deepest-first: %+v", mods)
+		}
+	}
+
+	byDir := make(map[string]Module, len(mods))
+	for _, m := range mods {
+		byDir[m.Dir] = m
+	}
+
+	root, ok := byDir["."]
+	if !ok {
+		t.Fatalf("Modules() missing root module, got %+v", mods)
+	}
+	if root.Path != "github.com/acme/monorepo" {
+		t.Errorf("root module Path = %q, want %q", root.Path, "github.com/acme/monorepo")
+	}
+
+	fork, ok := byDir["fork"]
+	if !ok {
+		t.Fatalf("Modules() missing fork module, got %+v", mods)
+	}
+	// The root's replace directive aliases the fork back to the upstream
+	// path, so anything importing github.com/upstream/lib collapses onto
+	// the fork's node.
+	if fork.Path != "github.com/upstream/lib" {
+		t.Errorf("fork module Path = %q, want it aliased to %q", fork.Path, "github.com/upstream/lib")
+	}
+
+	sub, ok := byDir["pkg/sub"]
+	if !ok {
+		t.Fatalf("Modules() missing pkg/sub module, got %+v", mods)
+	}
+	if sub.Path != "github.com/acme/sub" {
+		t.Errorf("pkg/sub module Path = %q, want %q (no replace directive targets it)", sub.Path, "github.com/acme/sub")
+	}
+}
+
+func TestApplyAliases(t *testing.T) {
+	tests := map[string]struct {
+		mods []Module
+		want map[string]string // dir -> expected Path after aliasing
+	}{
+		"local replace aliases the target module": {
+			mods: []Module{
+				{Path: "github.com/acme/monorepo/fork", Dir: "fork"},
+				{Path: "github.com/acme/monorepo", Dir: ".", Replace: map[string]string{"github.com/upstream/lib": "./fork"}},
+			},
+			want: map[string]string{
+				".":    "github.com/acme/monorepo",
+				"fork": "github.com/upstream/lib",
+			},
+		},
+		"non-local replace is ignored": {
+			mods: []Module{
+				{Path: "github.com/acme/monorepo", Dir: ".", Replace: map[string]string{"github.com/upstream/lib": "github.com/other/lib v1.2.3"}},
+			},
+			want: map[string]string{
+				".": "github.com/acme/monorepo",
+			},
+		},
+		"replace with no matching module directory is ignored": {
+			mods: []Module{
+				{Path: "github.com/acme/monorepo", Dir: ".", Replace: map[string]string{"github.com/upstream/lib": "./missing"}},
+			},
+			want: map[string]string{
+				".": "github.com/acme/monorepo",
+			},
+		},
+		"replace is resolved relative to the replacing module's directory": {
+			mods: []Module{
+				{Path: "github.com/acme/monorepo/pkg/fork", Dir: "pkg/fork"},
+				{Path: "github.com/acme/monorepo/pkg", Dir: "pkg", Replace: map[string]string{"github.com/upstream/lib": "./fork"}},
+			},
+			want: map[string]string{
+				"pkg":      "github.com/acme/monorepo/pkg",
+				"pkg/fork": "github.com/upstream/lib",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			applyAliases(tc.mods)
+
+			for _, m := range tc.mods {
+				if want := tc.want[m.Dir]; m.Path != want {
+					t.Errorf("module %q Path = %q, want %q", m.Dir, m.Path, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNearestAndPackageName(t *testing.T) {
+	// Deepest directory first, as Modules returns them.
+	mods := []Module{
+		{Path: "github.com/acme/monorepo/fork", Dir: "fork"},
+		{Path: "github.com/acme/monorepo", Dir: "."},
+	}
+
+	tests := map[string]struct {
+		file    string
+		wantDir string
+		wantPkg string
+	}{
+		"file in the root module": {
+			file:    "main.go",
+			wantDir: ".",
+			wantPkg: "github.com/acme/monorepo",
+		},
+		"file in a nested module": {
+			file:    "fork/sub/file.go",
+			wantDir: "fork",
+			wantPkg: "github.com/acme/monorepo/fork/sub",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			mod, ok := Nearest(mods, tc.file)
+			if !ok {
+				t.Fatalf("Nearest(%q) = _, false, want a match", tc.file)
+			}
+			if mod.Dir != tc.wantDir {
+				t.Errorf("Nearest(%q).Dir = %q, want %q", tc.file, mod.Dir, tc.wantDir)
+			}
+
+			if got := PackageName(mods, tc.file); got != tc.wantPkg {
+				t.Errorf("PackageName(%q) = %q, want %q", tc.file, got, tc.wantPkg)
+			}
+		})
+	}
+}
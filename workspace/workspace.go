@@ -0,0 +1,59 @@
+// Package workspace turns the command line into one or more repositories to
+// scan together, and resolves nested go.mod files and replace directives
+// within each so a renderer can present a single cross-module graph.
+package workspace
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/mod/modfile"
+
+	"github.com/suessflorian/go-dep-visual/repofetch"
+)
+
+// Target is a single repository to scan.
+type Target struct {
+	// Label identifies the target in log messages: the URL or local path
+	// given on the command line, or the go.work file's directory.
+	Label string
+	Fetch repofetch.Options
+}
+
+// ParseArgs turns command-line positional arguments into scan Targets. A
+// single argument naming a go.work file resolves to one Target rooted at
+// its directory; anything else is one Target per argument (a remote URL or
+// a local path).
+func ParseArgs(args []string) ([]Target, error) {
+	if len(args) == 1 && strings.HasSuffix(args[0], "go.work") {
+		return parseGoWork(args[0])
+	}
+
+	targets := make([]Target, len(args))
+	for i, arg := range args {
+		targets[i] = Target{Label: arg, Fetch: repofetch.Options{URL: arg}}
+	}
+	return targets, nil
+}
+
+// parseGoWork resolves a go.work file to a single Target rooted at its
+// directory. A go.work file's `use` entries name module subdirectories of
+// the one monorepo checkout it lives in, not independent repositories, so
+// there is exactly one clone/open to perform; Modules (called once that
+// Target is fetched) walks the resulting tree and resolves the nested
+// modules and replace directives those `use` entries describe.
+func parseGoWork(path string) ([]Target, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	if _, err := modfile.ParseWork(path, data, nil); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	dir := filepath.Dir(path)
+	return []Target{{Label: dir, Fetch: repofetch.Options{URL: dir}}}, nil
+}